@@ -0,0 +1,237 @@
+// Package alerting evaluates threshold rules against each peer's rolling
+// probe statistics and dispatches alert/resolve events to a pluggable
+// Sink when a rule starts or stops firing.
+package alerting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/camartinez04/network_monitor_go/config"
+	"github.com/camartinez04/network_monitor_go/prober"
+)
+
+// Event is a single alert transition: either a rule starting to fire or
+// an active alert clearing.
+type Event struct {
+	Peer      string
+	Interface string
+	Rule      string
+	Value     float64
+	Since     time.Time
+	ClusterID string
+	Resolved  bool
+}
+
+// Sink delivers an Event to whatever alerting backend it wraps.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// sample is one probe cycle's raw data for a peer, kept long enough to
+// satisfy the widest rule window currently configured.
+type sample struct {
+	at          time.Time
+	sent        int
+	received    int
+	rtts        []time.Duration
+	tcpFailures int
+}
+
+// ruleState tracks whether a (peer, rule) pair is currently firing, and
+// since when its condition has been continuously true.
+type ruleState struct {
+	conditionSince time.Time
+	firing         bool
+}
+
+// Evaluator maintains the rolling per-peer windows and per-(peer,rule)
+// firing state needed to turn config.AlertRule thresholds into Events.
+type Evaluator struct {
+	clusterID string
+	sinks     []Sink
+
+	mu      sync.Mutex
+	rules   []config.AlertRule
+	windows map[string][]sample
+	state   map[string]*ruleState
+}
+
+// NewEvaluator builds an Evaluator that sends to sinks, reporting
+// clusterID in every event it emits. It starts with no rules; call
+// SetRules to configure them.
+func NewEvaluator(clusterID string, sinks ...Sink) *Evaluator {
+	return &Evaluator{
+		clusterID: clusterID,
+		sinks:     sinks,
+		windows:   make(map[string][]sample),
+		state:     make(map[string]*ruleState),
+	}
+}
+
+// SetRules replaces the active rule set, picked up on the next Record.
+func (e *Evaluator) SetRules(rules []config.AlertRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = rules
+}
+
+// Record feeds one probe cycle's result into peer's rolling window and
+// evaluates every rule against the updated window, emitting any alert or
+// resolve events that result.
+func (e *Evaluator) Record(ctx context.Context, interfaceName, peer string, res prober.Result) {
+	e.mu.Lock()
+	now := time.Now()
+
+	tcpFailures := 0
+	for _, tcp := range res.TCP {
+		if !tcp.Connected {
+			tcpFailures++
+		}
+	}
+
+	e.windows[peer] = append(e.windows[peer], sample{
+		at:          now,
+		sent:        res.Sent,
+		received:    res.Received,
+		rtts:        res.RTTs,
+		tcpFailures: tcpFailures,
+	})
+	e.windows[peer] = pruneOlderThan(e.windows[peer], now, widestWindow(e.rules))
+
+	rules := e.rules
+	samples := e.windows[peer]
+	e.mu.Unlock()
+
+	for _, rule := range rules {
+		e.evaluateRule(ctx, interfaceName, peer, rule, samples, now)
+	}
+}
+
+func (e *Evaluator) evaluateRule(ctx context.Context, interfaceName, peer string, rule config.AlertRule, samples []sample, now time.Time) {
+	window := pruneOlderThan(samples, now, time.Duration(rule.ForSecs)*time.Second)
+	value, ok := metricValue(rule.Metric, window)
+	if !ok {
+		return
+	}
+
+	key := peer + "|" + rule.Name
+	triggered := compare(rule.Operator, value, rule.Threshold)
+
+	e.mu.Lock()
+	st, exists := e.state[key]
+	if !exists {
+		st = &ruleState{}
+		e.state[key] = st
+	}
+
+	var event *Event
+	switch {
+	case triggered && !st.firing:
+		if st.conditionSince.IsZero() {
+			st.conditionSince = now
+		}
+		if now.Sub(st.conditionSince) >= time.Duration(rule.ForSecs)*time.Second {
+			st.firing = true
+			event = &Event{Peer: peer, Interface: interfaceName, Rule: rule.Name, Value: value, Since: st.conditionSince, ClusterID: e.clusterID}
+		}
+	case !triggered && st.firing:
+		st.firing = false
+		st.conditionSince = time.Time{}
+		event = &Event{Peer: peer, Interface: interfaceName, Rule: rule.Name, Value: value, Since: now, ClusterID: e.clusterID, Resolved: true}
+	case !triggered:
+		st.conditionSince = time.Time{}
+	}
+	e.mu.Unlock()
+
+	if event != nil {
+		e.dispatch(ctx, *event)
+	}
+}
+
+func (e *Evaluator) dispatch(ctx context.Context, event Event) {
+	for _, sink := range e.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			continue
+		}
+	}
+}
+
+// metricValue computes the named metric over window, returning false if
+// there isn't enough data to evaluate it yet.
+func metricValue(metric string, window []sample) (float64, bool) {
+	if len(window) == 0 {
+		return 0, false
+	}
+
+	switch metric {
+	case "loss_pct":
+		var sent, received int
+		for _, s := range window {
+			sent += s.sent
+			received += s.received
+		}
+		if sent == 0 {
+			return 0, false
+		}
+		return 100 * float64(sent-received) / float64(sent), true
+
+	case "rtt_p95_ms":
+		var rtts []time.Duration
+		for _, s := range window {
+			rtts = append(rtts, s.rtts...)
+		}
+		if len(rtts) == 0 {
+			return 0, false
+		}
+		sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+		idx := int(float64(len(rtts)-1) * 0.95)
+		return float64(rtts[idx]) / float64(time.Millisecond), true
+
+	case "tcp_connect_failures":
+		var failures int
+		for _, s := range window {
+			failures += s.tcpFailures
+		}
+		return float64(failures), true
+
+	default:
+		return 0, false
+	}
+}
+
+func compare(operator string, value, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	default:
+		return false
+	}
+}
+
+func widestWindow(rules []config.AlertRule) time.Duration {
+	var widest time.Duration
+	for _, r := range rules {
+		if d := time.Duration(r.ForSecs) * time.Second; d > widest {
+			widest = d
+		}
+	}
+	return widest
+}
+
+func pruneOlderThan(samples []sample, now time.Time, window time.Duration) []sample {
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(samples) && samples[i].at.Before(cutoff) {
+		i++
+	}
+	return samples[i:]
+}
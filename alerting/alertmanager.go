@@ -0,0 +1,74 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AlertmanagerSink POSTs events to Alertmanager's v2 alerts API.
+type AlertmanagerSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewAlertmanagerSink returns an AlertmanagerSink posting to
+// baseURL + "/api/v2/alerts".
+func NewAlertmanagerSink(baseURL string) *AlertmanagerSink {
+	return &AlertmanagerSink{
+		URL:    baseURL + "/api/v2/alerts",
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type amAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt,omitempty"`
+}
+
+// Send posts event to Alertmanager, setting EndsAt to now when the event
+// is a resolve so Alertmanager clears the alert immediately.
+func (a *AlertmanagerSink) Send(ctx context.Context, event Event) error {
+	alert := amAlert{
+		Labels: map[string]string{
+			"alertname": event.Rule,
+			"peer":      event.Peer,
+			"interface": event.Interface,
+			"cluster":   event.ClusterID,
+		},
+		Annotations: map[string]string{
+			"value": fmt.Sprintf("%v", event.Value),
+		},
+		StartsAt: event.Since,
+	}
+	if event.Resolved {
+		alert.EndsAt = time.Now()
+	}
+
+	body, err := json.Marshal([]amAlert{alert})
+	if err != nil {
+		return fmt.Errorf("marshal alertmanager payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build alertmanager request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post to alertmanager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("alertmanager returned status %d", resp.StatusCode)
+}
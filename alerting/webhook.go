@@ -0,0 +1,84 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookSink POSTs a JSON payload describing the event to a generic
+// webhook URL, retrying with exponential backoff on failure.
+type WebhookSink struct {
+	URL        string
+	Client     *http.Client
+	MaxRetries int
+}
+
+// NewWebhookSink returns a WebhookSink posting to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		URL:        url,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		MaxRetries: 3,
+	}
+}
+
+type webhookPayload struct {
+	Peer      string    `json:"peer"`
+	Interface string    `json:"interface"`
+	Rule      string    `json:"rule"`
+	Value     float64   `json:"value"`
+	Since     time.Time `json:"since"`
+	ClusterID string    `json:"cluster_id"`
+	Resolved  bool      `json:"resolved"`
+}
+
+// Send posts event as JSON, retrying with exponential backoff.
+func (w *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(webhookPayload{
+		Peer:      event.Peer,
+		Interface: event.Interface,
+		Rule:      event.Rule,
+		Value:     event.Value,
+		Since:     event.Since,
+		ClusterID: event.ClusterID,
+		Resolved:  event.Resolved,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	var lastErr error
+	backoff := 500 * time.Millisecond
+	for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("build webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := w.Client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("webhook delivery failed after %d retries: %w", w.MaxRetries, lastErr)
+}
@@ -0,0 +1,77 @@
+// Package config defines the network monitor's on-disk configuration and
+// loads it from TOML, so that adding a new knob no longer requires
+// regenerating the systemd unit's flag list.
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// AlertRule is a single threshold rule evaluated against a peer's rolling
+// statistics, e.g. "loss_pct > 20 for 30s".
+type AlertRule struct {
+	Name      string  `toml:"name"`
+	Metric    string  `toml:"metric"`    // loss_pct, rtt_p95_ms, tcp_connect_failures
+	Operator  string  `toml:"operator"`  // >, >=, <, <=
+	Threshold float64 `toml:"threshold"`
+	ForSecs   int     `toml:"for_secs"`
+}
+
+// Config is the full set of knobs the monitor takes, either from this
+// file or from the individual command-line flags it overrides.
+type Config struct {
+	Interface       string      `toml:"interface"`
+	Frequency       int         `toml:"frequency"`
+	ProbeType       string      `toml:"probe_type"`
+	TCPPorts        []int       `toml:"tcp_ports"`
+	MetricsAddr     string      `toml:"metrics_addr"`
+	LogLevel        string      `toml:"log_level"`
+	WebhookURL      string      `toml:"webhook_url"`
+	AlertmanagerURL string      `toml:"alertmanager_url"`
+	ExcludedPeers   []string    `toml:"excluded_peers"`
+	AlertRules      []AlertRule `toml:"alert_rules"`
+}
+
+// Default returns the configuration matching the tool's historical flag
+// defaults, used to seed a freshly installed config file.
+func Default() Config {
+	return Config{
+		Frequency:   3,
+		ProbeType:   "icmp",
+		MetricsAddr: ":9155",
+		LogLevel:    "info",
+	}
+}
+
+// Load decodes path onto base, so that any field the file doesn't set
+// keeps base's value. Callers typically seed base with Default() or with
+// the process's current flag values.
+func Load(path string, base Config) (Config, error) {
+	cfg := base
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		return base, fmt.Errorf("decode config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// WriteDefault writes the default configuration to path unless a file
+// already exists there.
+func WriteDefault(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create config %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := toml.NewEncoder(f).Encode(Default()); err != nil {
+		return fmt.Errorf("encode default config: %w", err)
+	}
+	return nil
+}
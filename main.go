@@ -5,23 +5,35 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
-	"github.com/rifflock/lfshook"
-	"github.com/sirupsen/logrus"
 	"io"
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+
+	"github.com/camartinez04/network_monitor_go/alerting"
+	"github.com/camartinez04/network_monitor_go/config"
+	"github.com/camartinez04/network_monitor_go/metrics"
+	"github.com/camartinez04/network_monitor_go/prober"
+	"github.com/camartinez04/network_monitor_go/remotelog"
+	"github.com/coreos/go-systemd/v22/daemon"
+	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+	"github.com/rifflock/lfshook"
+	"github.com/sirupsen/logrus"
 )
 
 const (
 	networkMonitorBin = "/opt/pwx/oci/rootfs/usr/local/bin/network_monitor"
 	serviceFile       = "/etc/systemd/system/portworx_network_monitor.service"
 	pxctlBin          = "/opt/pwx/bin/pxctl"
+	defaultConfigFile = "/etc/portworx/netmon.toml"
 )
 
 var (
@@ -73,18 +85,109 @@ func getLocalIP(logger *logrus.Logger) {
 	}
 }
 
-// pingNode pings the node from the specified interface
-func pingNode(ctx context.Context, interfaceName, node string, logger *logrus.Logger) (string, error) {
-	pingTimeout := 5 // Set the desired timeout in seconds
-	pingCmd := fmt.Sprintf("ping -I %s -c 1 -W %d %s", interfaceName, pingTimeout, node)
+// pingCount is the number of echo requests sent to each peer per cycle.
+const pingCount = 1
 
-	cmd := exec.CommandContext(ctx, "bash", "-c", pingCmd)
-	out, err := cmd.Output()
+// pingTimeout bounds how long a single probe waits for replies.
+const pingTimeout = 5 * time.Second
+
+// pingNode probes the node from the specified interface using the given
+// prober, then layers on TCP port checks when tcpProbe is non-nil, and
+// returns the combined result.
+func pingNode(ctx context.Context, p prober.Prober, tcpProbe *prober.TCPProbe, tcpPorts []int, interfaceName, node string, logger *logrus.Logger) (prober.Result, error) {
+	res, err := p.Probe(ctx, interfaceName, node, pingCount)
 	if err != nil {
 		logger.Errorf("Ping failed on node %s: %v", node, err)
-		return "", err
+		return res, err
+	}
+
+	if tcpProbe != nil && len(tcpPorts) > 0 {
+		tcpResults, err := tcpProbe.Probe(ctx, interfaceName, node, tcpPorts)
+		if err != nil {
+			logger.Errorf("TCP probe failed on node %s: %v", node, err)
+		} else {
+			res.TCP = tcpResults
+		}
+	}
+	return res, nil
+}
+
+// parseTCPPorts parses a comma-separated port list such as "9001,9002,9020".
+func parseTCPPorts(raw string) ([]int, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var ports []int
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		port, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tcp port %q: %w", field, err)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// readyPeers returns the peers readiness should be evaluated against:
+// every cluster node except localIP (which the probe loop skips) and any
+// explicitly excluded peer.
+func readyPeers(nodes []string, localIP string, excludedPeers []string) []string {
+	excluded := make(map[string]bool, len(excludedPeers))
+	for _, peer := range excludedPeers {
+		excluded[peer] = true
+	}
+
+	peers := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if node == localIP || excluded[node] {
+			continue
+		}
+		peers = append(peers, node)
 	}
-	return string(out), nil
+	return peers
+}
+
+// watchConfig reloads configPath into cfg whenever the process receives
+// SIGHUP, atomically swapping the pointer the main loop reads from so no
+// restart is required to pick up the change.
+func watchConfig(configPath string, cfg *atomic.Pointer[config.Config], logger *logrus.Logger) {
+	if configPath == "" {
+		return
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			reloaded, err := config.Load(configPath, *cfg.Load())
+			if err != nil {
+				logger.Errorf("Failed to reload config %s: %v", configPath, err)
+				continue
+			}
+			cfg.Store(&reloaded)
+			applyLogLevel(logger, reloaded.LogLevel)
+			logger.Infof("Reloaded config from %s", configPath)
+		}
+	}()
+}
+
+// applyLogLevel parses level and, if valid, sets it on logger. An empty
+// or unrecognized level is left as whatever the logger is already set
+// to, so a typo in the config can't silently go quiet.
+func applyLogLevel(logger *logrus.Logger, level string) {
+	if level == "" {
+		return
+	}
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		logger.Warnf("Invalid log_level %q, keeping current level: %v", level, err)
+		return
+	}
+	logger.SetLevel(parsed)
 }
 
 // removeService removes the systemd service
@@ -110,7 +213,7 @@ func removeService(logger *logrus.Logger) {
 }
 
 // installService installs the systemd service
-func installService(interfaceName string, frequency int, ip string, logger *logrus.Logger) {
+func installService(interfaceName string, frequency int, ip string, proberKind prober.Kind, metricsAddr, configPath string, logger *logrus.Logger) {
 	serviceDefinition := `
 [Unit]
 Description=portworx network monitor service
@@ -118,11 +221,13 @@ After=portworx.service
 StartLimitIntervalSec=0s
 
 [Service]
-Type=simple
-RuntimeMaxSec=1800s
+Type=notify
+NotifyAccess=main
+WatchdogSec=30s
 Restart=always
 User=root
 ExecStart=PING_CMD
+ExecReload=/bin/kill -HUP $MAINPID
 TasksMax=200
 MemoryMax=60M
 
@@ -130,7 +235,13 @@ MemoryMax=60M
 WantedBy=multi-user.target`
 	logger.Printf("Installing service with interface %s, frequency %d, ip %s...", interfaceName, frequency, ip)
 	removeService(logger)
-	pingCmd := fmt.Sprintf("%s -interface %s -frequency %d -ip %s -r", networkMonitorBin, interfaceName, frequency, ip)
+
+	if err := config.WriteDefault(configPath); err != nil {
+		logger.Warnf("Failed to write default config file: %v", err)
+	}
+
+	pingCmd := fmt.Sprintf("%s -interface %s -frequency %d -ip %s -prober %s -metrics-addr %s -config %s -r",
+		networkMonitorBin, interfaceName, frequency, ip, proberKind, metricsAddr, configPath)
 	serviceDefinition = strings.Replace(serviceDefinition, "PING_CMD", pingCmd, 1)
 	err := os.WriteFile(serviceFile, []byte(serviceDefinition), 0644)
 	if err != nil {
@@ -159,8 +270,29 @@ WantedBy=multi-user.target`
 	fmt.Println(string(out))
 }
 
-// setupLogging sets up logging to a file and returns a logrus logger
-func setupLogging() *logrus.Logger {
+// newFormatter returns the TextFormatter or JSONFormatter selected by
+// logFormat, sharing a FieldMap so the JSON stream uses the field names
+// Loki/ELK pipelines expect.
+func newFormatter(logFormat string) logrus.Formatter {
+	if logFormat == "json" {
+		return &logrus.JSONFormatter{
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime:  "timestamp",
+				logrus.FieldKeyLevel: "level",
+				logrus.FieldKeyMsg:   "message",
+			},
+		}
+	}
+	return &logrus.TextFormatter{
+		TimestampFormat: "2006-01-02 15:04:05",
+		FullTimestamp:   true,
+	}
+}
+
+// setupLogging sets up logging to a file and returns a logrus logger.
+// logFormat selects text or json output; remoteLogTarget, if set, ships
+// ERROR+ records to a remote collector via the remotelog hook.
+func setupLogging(logFormat, remoteLogTarget string) *logrus.Logger {
 	logPath := "/var/lib/osd/log/nw_mon_log/nw_mon.log"
 	err := os.MkdirAll(path.Dir(logPath), os.ModePerm)
 	if err != nil {
@@ -170,10 +302,7 @@ func setupLogging() *logrus.Logger {
 	// setting logrus logger
 	logger := logrus.New()
 
-	logger.SetFormatter(&logrus.TextFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-		FullTimestamp:   true,
-	})
+	logger.SetFormatter(newFormatter(logFormat))
 
 	defer func(file *os.File) {
 		err := file.Close()
@@ -202,13 +331,20 @@ func setupLogging() *logrus.Logger {
 		logrus.PanicLevel: logWriter,
 	}
 
-	lfHook := lfshook.NewHook(writeMap, &logrus.TextFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-	})
+	lfHook := lfshook.NewHook(writeMap, newFormatter(logFormat))
 
 	// add hook to logger
 	logger.AddHook(lfHook)
 
+	if remoteLogTarget != "" {
+		remoteHook, err := remotelog.NewHook(remoteLogTarget)
+		if err != nil {
+			logger.Errorf("Failed to set up remote log shipping: %v", err)
+		} else {
+			logger.AddHook(remoteHook)
+		}
+	}
+
 	// Output to stdout instead of the default stderr, also to the file
 	logger.SetOutput(io.MultiWriter(os.Stdout, logWriter))
 
@@ -217,16 +353,23 @@ func setupLogging() *logrus.Logger {
 
 // main function with sync.WaitGroup to wait for all goroutines to finish and prevent memory leaks
 func main() {
-	// setup logging
-	logger := setupLogging()
-
-	logger.Info("Set up logging for NW Monitor")
 	interfaceName := flag.String("interface", "", "interface to run ping from")
 	frequency := flag.Int("frequency", 3, "frequency for ping")
 	ip := flag.String("ip", "", "this node's ip")
 	r := flag.Bool("r", false, "Directly run script without scheduling systemd service")
+	proberFlag := flag.String("prober", string(prober.KindICMP), "prober implementation to use: icmp or exec")
+	metricsAddr := flag.String("metrics-addr", ":9155", "address to serve /metrics, /healthz and /readyz on")
+	tcpPortsFlag := flag.String("tcp-ports", "", "comma-separated list of Portworx service ports to TCP-probe alongside ICMP, e.g. 9001,9002,9020")
+	configPath := flag.String("config", defaultConfigFile, "path to the TOML config file; values here override the flags above")
+	logFormat := flag.String("log-format", "text", "log output format: text or json")
+	remoteLog := flag.String("remote-log", "", "ship ERROR+ logs to a remote collector, e.g. tcp://host:port or syslog://host:port")
 	flag.Parse()
 
+	// setup logging
+	logger := setupLogging(*logFormat, *remoteLog)
+
+	logger.Info("Set up logging for NW Monitor")
+
 	if *ip == "" {
 		getLocalIP(logger)
 	} else {
@@ -237,31 +380,129 @@ func main() {
 		getNodes(logger)
 	}
 
+	tcpPorts, err := parseTCPPorts(*tcpPortsFlag)
+	if err != nil {
+		logger.Fatalf("Failed to parse -tcp-ports: %v", err)
+	}
+
+	flagCfg := config.Config{
+		Interface:   *interfaceName,
+		Frequency:   *frequency,
+		ProbeType:   *proberFlag,
+		TCPPorts:    tcpPorts,
+		MetricsAddr: *metricsAddr,
+	}
+	initialCfg := flagCfg
+	if _, statErr := os.Stat(*configPath); statErr == nil {
+		initialCfg, err = config.Load(*configPath, flagCfg)
+		if err != nil {
+			logger.Fatalf("Failed to load config %s: %v", *configPath, err)
+		}
+	}
+	applyLogLevel(logger, initialCfg.LogLevel)
+
 	if !*r {
-		installService(*interfaceName, *frequency, localIP, logger)
+		installService(initialCfg.Interface, initialCfg.Frequency, localIP, prober.Kind(initialCfg.ProbeType), initialCfg.MetricsAddr, *configPath, logger)
 	} else {
+		cfg := &atomic.Pointer[config.Config]{}
+		cfg.Store(&initialCfg)
+		watchConfig(*configPath, cfg, logger)
+
+		collectors := metrics.New()
+		collectors.SetPeers(readyPeers(nodes, localIP, initialCfg.ExcludedPeers))
+		collectors.SetReadyWindow(2 * time.Duration(initialCfg.Frequency) * time.Second)
+		go func() {
+			if err := collectors.Serve(initialCfg.MetricsAddr); err != nil {
+				logger.Errorf("Metrics server stopped: %v", err)
+			}
+		}()
+		collectors.ClusterNodes.Set(float64(len(nodes)))
+
+		clusterID, err := os.Hostname()
+		if err != nil {
+			clusterID = localIP
+		}
+		evaluator := alerting.NewEvaluator(clusterID)
+
+		tcpProbe := prober.NewTCPProbe(pingTimeout)
+		var activeProberKind prober.Kind
+		var activeWebhookURL string
+		var activeAlertmanagerURL string
+		var p prober.Prober
+		var cycleID int
+		var readyNotified bool
+
+		runCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		go watchdog(runCtx, logger)
+
+	runLoop:
 		for {
-			ctx, cancel := context.WithCancel(context.Background())
+			select {
+			case <-runCtx.Done():
+				break runLoop
+			default:
+			}
+
+			current := cfg.Load()
+			cycleID++
+
+			if prober.Kind(current.ProbeType) != activeProberKind {
+				activeProberKind = prober.Kind(current.ProbeType)
+				p, err = prober.New(activeProberKind, pingTimeout)
+				if err != nil {
+					logger.Fatalf("Failed to create prober: %v", err)
+				}
+			}
+
+			if current.WebhookURL != activeWebhookURL || current.AlertmanagerURL != activeAlertmanagerURL {
+				activeWebhookURL = current.WebhookURL
+				activeAlertmanagerURL = current.AlertmanagerURL
+
+				var sinks []alerting.Sink
+				if activeWebhookURL != "" {
+					sinks = append(sinks, alerting.NewWebhookSink(activeWebhookURL))
+				}
+				if activeAlertmanagerURL != "" {
+					sinks = append(sinks, alerting.NewAlertmanagerSink(activeAlertmanagerURL))
+				}
+				evaluator = alerting.NewEvaluator(clusterID, sinks...)
+			}
+			evaluator.SetRules(current.AlertRules)
+
+			excluded := make(map[string]bool, len(current.ExcludedPeers))
+			for _, peer := range current.ExcludedPeers {
+				excluded[peer] = true
+			}
+			collectors.SetPeers(readyPeers(nodes, localIP, current.ExcludedPeers))
+
+			ctx, cancel := context.WithCancel(runCtx)
 
 			// context timeout for 5 minutes, for loop will restart after this to release resources
 			go func() {
-				<-time.After(5 * time.Minute)
-				cancel()
+				select {
+				case <-time.After(5 * time.Minute):
+					cancel()
+				case <-runCtx.Done():
+				}
 			}()
 
 			var wg sync.WaitGroup
-			results := make(chan string, len(nodes))
+			results := make(chan prober.Result, len(nodes))
 			errs := make(chan error, len(nodes))
 			for _, node := range nodes {
 				if localIP == node {
 					logger.Println("skipping local node")
 					continue
 				}
+				if excluded[node] {
+					continue
+				}
 				wg.Add(1)
 				pingContext, pingCancel := context.WithCancel(ctx)
 				go func(n string) {
 					defer wg.Done()
-					res, err := pingNode(pingContext, *interfaceName, n, logger)
+					res, err := pingNode(pingContext, p, tcpProbe, current.TCPPorts, current.Interface, n, logger)
 					if err != nil {
 						errs <- err
 					} else {
@@ -278,13 +519,69 @@ func main() {
 
 			// make sure all goroutines finish
 			for result := range results {
-				logger.Print(result)
+				logger.WithFields(logrus.Fields{
+					"peer":      result.Peer,
+					"interface": current.Interface,
+					"rtt_ms":    float64(result.AvgRTT) / float64(time.Millisecond),
+					"loss_pct":  result.LossPct,
+					"cycle_id":  cycleID,
+					"local_ip":  localIP,
+				}).Info(result)
+				collectors.Observe(localIP, result.Peer, current.Interface, result.Sent, result.Received, result.RTTs)
+				for _, tcp := range result.TCP {
+					collectors.ObserveTCP(localIP, result.Peer, current.Interface, tcp.Port, tcp.Connected, tcp.Latency)
+				}
+				evaluator.Record(ctx, current.Interface, result.Peer, result)
 			}
 			for err := range errs {
-				logger.Errorf("Ping failed: %v", err)
+				logger.WithFields(logrus.Fields{
+					"interface": current.Interface,
+					"cycle_id":  cycleID,
+					"local_ip":  localIP,
+				}).Errorf("Ping failed: %v", err)
 			}
+
+			if !readyNotified {
+				if _, err := daemon.SdNotify(false, daemon.SdNotifyReady); err != nil {
+					logger.Warnf("Failed to notify systemd of readiness: %v", err)
+				}
+				readyNotified = true
+			}
+
 			// If you want to wait for a certain duration between each set of pings:
-			time.Sleep(time.Duration(*frequency) * time.Second)
+			select {
+			case <-time.After(time.Duration(current.Frequency) * time.Second):
+			case <-runCtx.Done():
+				break runLoop
+			}
+		}
+
+		logger.Info("Shutting down, waiting for in-flight probes to finish")
+		if _, err := daemon.SdNotify(false, daemon.SdNotifyStopping); err != nil {
+			logger.Warnf("Failed to notify systemd of shutdown: %v", err)
+		}
+	}
+}
+
+// watchdog pings systemd's watchdog at half its configured interval so
+// the service manager only restarts us if we actually stop responding,
+// instead of on a fixed RuntimeMaxSec timer regardless of health.
+func watchdog(ctx context.Context, logger *logrus.Logger) {
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil || interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := daemon.SdNotify(false, daemon.SdNotifyWatchdog); err != nil {
+				logger.Warnf("Failed to notify systemd watchdog: %v", err)
+			}
 		}
 	}
 }
@@ -0,0 +1,177 @@
+// Package metrics exposes the network monitor's Prometheus collectors and
+// the HTTP endpoints (/metrics, /healthz, /readyz) they're served on.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "portworx_netmon"
+
+// Collectors holds every metric the main loop writes to after each ping.
+type Collectors struct {
+	RTTSeconds       *prometheus.HistogramVec
+	ProbesSent       *prometheus.CounterVec
+	ProbesRecv       *prometheus.CounterVec
+	ProbesLost       *prometheus.CounterVec
+	LastSuccess      *prometheus.GaugeVec
+	ClusterNodes     prometheus.Gauge
+	TCPConnectTotal  *prometheus.CounterVec
+	TCPHandshakeSecs *prometheus.HistogramVec
+
+	registry *prometheus.Registry
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+	peers    []string
+	ready    time.Duration
+}
+
+// New creates and registers the collectors against a fresh registry.
+func New() *Collectors {
+	reg := prometheus.NewRegistry()
+
+	c := &Collectors{
+		RTTSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rtt_seconds",
+			Help:      "Round-trip time of successful echo replies.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+		}, []string{"src_ip", "dst_ip", "interface"}),
+		ProbesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "probes_sent_total",
+			Help:      "Total echo requests sent per peer.",
+		}, []string{"src_ip", "dst_ip", "interface"}),
+		ProbesRecv: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "probes_received_total",
+			Help:      "Total echo replies received per peer.",
+		}, []string{"src_ip", "dst_ip", "interface"}),
+		ProbesLost: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "probes_lost_total",
+			Help:      "Total echo requests that went unanswered per peer.",
+		}, []string{"src_ip", "dst_ip", "interface"}),
+		LastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful probe per peer.",
+		}, []string{"src_ip", "dst_ip", "interface"}),
+		ClusterNodes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cluster_nodes",
+			Help:      "Number of nodes currently known in the cluster.",
+		}),
+		TCPConnectTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tcp_connect_total",
+			Help:      "TCP connect attempts per peer port, labeled by result.",
+		}, []string{"src_ip", "dst_ip", "interface", "port", "result"}),
+		TCPHandshakeSecs: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tcp_handshake_seconds",
+			Help:      "Latency of successful TCP connects per peer port.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+		}, []string{"src_ip", "dst_ip", "interface", "port"}),
+		registry: reg,
+		lastSeen: make(map[string]time.Time),
+	}
+
+	reg.MustRegister(c.RTTSeconds, c.ProbesSent, c.ProbesRecv, c.ProbesLost, c.LastSuccess, c.ClusterNodes,
+		c.TCPConnectTotal, c.TCPHandshakeSecs)
+	return c
+}
+
+// Observe records the outcome of one probe cycle against a peer.
+func (c *Collectors) Observe(srcIP, dstIP, interfaceName string, sent, received int, rtts []time.Duration) {
+	c.ProbesSent.WithLabelValues(srcIP, dstIP, interfaceName).Add(float64(sent))
+	c.ProbesRecv.WithLabelValues(srcIP, dstIP, interfaceName).Add(float64(received))
+	c.ProbesLost.WithLabelValues(srcIP, dstIP, interfaceName).Add(float64(sent - received))
+
+	for _, rtt := range rtts {
+		c.RTTSeconds.WithLabelValues(srcIP, dstIP, interfaceName).Observe(rtt.Seconds())
+	}
+
+	if received > 0 {
+		now := time.Now()
+		c.LastSuccess.WithLabelValues(srcIP, dstIP, interfaceName).Set(float64(now.Unix()))
+		c.mu.Lock()
+		c.lastSeen[dstIP] = now
+		c.mu.Unlock()
+	}
+}
+
+// ObserveTCP records the outcome of one TCP connect probe against a
+// single peer port.
+func (c *Collectors) ObserveTCP(srcIP, dstIP, interfaceName string, port int, connected bool, latency time.Duration) {
+	portLabel := fmt.Sprintf("%d", port)
+	result := "failure"
+	if connected {
+		result = "success"
+		c.TCPHandshakeSecs.WithLabelValues(srcIP, dstIP, interfaceName, portLabel).Observe(latency.Seconds())
+	}
+	c.TCPConnectTotal.WithLabelValues(srcIP, dstIP, interfaceName, portLabel, result).Inc()
+}
+
+// SetPeers records the full set of peers readiness is evaluated against.
+func (c *Collectors) SetPeers(peers []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peers = append([]string(nil), peers...)
+}
+
+// SetReadyWindow sets how long a peer may go without a successful probe
+// before the monitor is considered not-ready.
+func (c *Collectors) SetReadyWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ready = window
+}
+
+// Ready reports whether every known peer has had a successful probe
+// within the configured readiness window.
+func (c *Collectors) Ready() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.peers) == 0 {
+		return false
+	}
+	for _, peer := range c.peers {
+		seen, ok := c.lastSeen[peer]
+		if !ok || time.Since(seen) > c.ready {
+			return false
+		}
+	}
+	return true
+}
+
+// Serve starts an HTTP server exposing /metrics, /healthz and /readyz on
+// addr. It runs until the process exits or the listener fails, so callers
+// typically invoke it in its own goroutine.
+func (c *Collectors) Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !c.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "not ready")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ready")
+	})
+
+	return http.ListenAndServe(addr, mux)
+}
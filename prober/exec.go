@@ -0,0 +1,55 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// execProber shells out to the system ping binary. It exists purely as
+// an opt-in fallback for hosts where the raw ICMP socket the icmp prober
+// opens isn't available; it cannot report per-reply TTL.
+type execProber struct {
+	timeout time.Duration
+}
+
+var rttRegexp = regexp.MustCompile(`time=([0-9.]+) ms`)
+
+func (p *execProber) Probe(ctx context.Context, interfaceName, peer string, count int) (Result, error) {
+	result := Result{Peer: peer, Sent: count}
+	var samples []time.Duration
+
+	timeoutSec := int(p.timeout.Seconds())
+	if timeoutSec < 1 {
+		timeoutSec = 1
+	}
+
+	for i := 0; i < count; i++ {
+		args := []string{"-I", interfaceName, "-c", "1", "-W", strconv.Itoa(timeoutSec), peer}
+		cmd := exec.CommandContext(ctx, "ping", args...)
+		out, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		result.Received++
+		if m := rttRegexp.FindSubmatch(out); m != nil {
+			if ms, err := strconv.ParseFloat(string(m[1]), 64); err == nil {
+				samples = append(samples, time.Duration(ms*float64(time.Millisecond)))
+			}
+		}
+	}
+
+	if result.Sent > 0 {
+		result.LossPct = 100 * float64(result.Sent-result.Received) / float64(result.Sent)
+	}
+	result.MinRTT, result.AvgRTT, result.MaxRTT, result.StdDevRTT = stats(samples)
+	result.RTTs = samples
+
+	if result.Received == 0 && result.Sent > 0 {
+		return result, fmt.Errorf("all %d pings to %s failed", result.Sent, peer)
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// icmpProber sends native ICMP echo requests and matches replies by ICMP
+// ID and sequence number, bound to a specific interface via
+// SO_BINDTODEVICE so probes leave on the same link the old `ping -I`
+// invocation used.
+type icmpProber struct {
+	timeout time.Duration
+}
+
+func (p *icmpProber) Probe(ctx context.Context, interfaceName, peer string, count int) (Result, error) {
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return Result{}, fmt.Errorf("listen icmp: %w", err)
+	}
+	defer conn.Close()
+
+	if interfaceName != "" {
+		if err := bindToDevice(conn, interfaceName); err != nil {
+			return Result{}, fmt.Errorf("bind to device %s: %w", interfaceName, err)
+		}
+	}
+
+	if err := conn.IPv4PacketConn().SetControlMessage(ipv4.FlagTTL, true); err != nil {
+		return Result{}, fmt.Errorf("enable ttl control message: %w", err)
+	}
+
+	dst, err := net.ResolveIPAddr("ip4", peer)
+	if err != nil {
+		return Result{}, fmt.Errorf("resolve %s: %w", peer, err)
+	}
+
+	result := Result{Peer: peer, Sent: count}
+	id := nextEchoID()
+	var samples []time.Duration
+
+	for seq := 1; seq <= count; seq++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Code: 0,
+			Body: &icmp.Echo{
+				ID:   id,
+				Seq:  seq,
+				Data: []byte("network-monitor"),
+			},
+		}
+		wb, err := msg.Marshal(nil)
+		if err != nil {
+			return Result{}, fmt.Errorf("marshal echo request: %w", err)
+		}
+
+		sendTime := time.Now()
+		if _, err := conn.WriteTo(wb, dst); err != nil {
+			continue
+		}
+
+		rtt, ttl, ok := p.readReply(conn, id, seq, dst, sendTime)
+		if ok {
+			result.Received++
+			samples = append(samples, rtt)
+			result.TTLs = append(result.TTLs, ttl)
+		}
+	}
+
+	if result.Sent > 0 {
+		result.LossPct = 100 * float64(result.Sent-result.Received) / float64(result.Sent)
+	}
+	result.MinRTT, result.AvgRTT, result.MaxRTT, result.StdDevRTT = stats(samples)
+	result.RTTs = samples
+	return result, nil
+}
+
+// readReply blocks until a reply matching id/seq *from dst* arrives or
+// the read deadline expires, returning the round-trip time (measured
+// from sendTime, when the echo request was written) and reported TTL.
+// Matching on id/seq alone isn't enough: every prober's raw ICMP socket
+// receives every peer's replies, so a reply from the wrong peer that
+// happens to reuse our id/seq must be rejected by source address too.
+func (p *icmpProber) readReply(conn *icmp.PacketConn, id, seq int, dst net.Addr, sendTime time.Time) (time.Duration, int, bool) {
+	deadline := sendTime.Add(p.timeout)
+	buf := make([]byte, 1500)
+
+	for time.Now().Before(deadline) {
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return 0, 0, false
+		}
+		n, cm, src, err := conn.IPv4PacketConn().ReadFrom(buf)
+		if err != nil {
+			return 0, 0, false
+		}
+		if src == nil || src.String() != dst.String() {
+			continue
+		}
+
+		rm, err := icmp.ParseMessage(1, buf[:n])
+		if err != nil {
+			continue
+		}
+		echo, ok := rm.Body.(*icmp.Echo)
+		if !ok || rm.Type != ipv4.ICMPTypeEchoReply || echo.ID != id || echo.Seq != seq {
+			continue
+		}
+
+		ttl := 0
+		if cm != nil {
+			ttl = cm.TTL
+		}
+		return time.Since(sendTime), ttl, true
+	}
+	return 0, 0, false
+}
+
+// nextEchoID returns a process-wide unique ICMP echo ID for each probe,
+// so concurrent probers (one per peer, fanned out from the main loop)
+// don't all share the same id the way a single shared os.Getpid() would.
+var echoIDCounter int32
+
+func nextEchoID() int {
+	return int(atomic.AddInt32(&echoIDCounter, 1)) & 0xffff
+}
+
+// bindToDevice restricts the socket underlying conn to interfaceName via
+// SO_BINDTODEVICE, mirroring the `-I` flag of the system ping binary.
+func bindToDevice(conn *icmp.PacketConn, interfaceName string) error {
+	sc, err := conn.IPv4PacketConn().SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	if err := sc.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, interfaceName)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
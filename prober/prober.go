@@ -0,0 +1,105 @@
+// Package prober implements the network reachability checks used by the
+// network monitor main loop: sending echo requests to a peer and turning
+// the replies into aggregate statistics.
+package prober
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Kind selects which Prober implementation is used for a ping cycle.
+type Kind string
+
+const (
+	// KindICMP sends native ICMP echo requests via golang.org/x/net/icmp.
+	KindICMP Kind = "icmp"
+	// KindExec shells out to the system ping binary, matching the
+	// tool's original behavior. Kept as an opt-in fallback for hosts
+	// where raw/dgram ICMP sockets are unavailable.
+	KindExec Kind = "exec"
+)
+
+// Result holds the aggregate statistics for one probe cycle against a
+// single peer.
+type Result struct {
+	Peer      string
+	Sent      int
+	Received  int
+	LossPct   float64
+	MinRTT    time.Duration
+	AvgRTT    time.Duration
+	MaxRTT    time.Duration
+	StdDevRTT time.Duration
+	TTLs      []int
+	// RTTs holds the individual round-trip samples behind the
+	// aggregates above, one per successful reply, so callers can feed
+	// them into a histogram instead of just the summary stats.
+	RTTs []time.Duration
+	// TCP holds the per-port TCP connect results gathered alongside the
+	// ICMP probe, if TCP port checks were configured.
+	TCP []TCPResult
+}
+
+// String renders the result as space-separated key=value pairs, followed
+// by one tcp:PORT=up(latency) or tcp:PORT=down(error) entry per TCP result.
+func (r Result) String() string {
+	s := fmt.Sprintf("peer=%s sent=%d received=%d loss=%.1f%% min=%s avg=%s max=%s stddev=%s",
+		r.Peer, r.Sent, r.Received, r.LossPct, r.MinRTT, r.AvgRTT, r.MaxRTT, r.StdDevRTT)
+	for _, tcp := range r.TCP {
+		if tcp.Connected {
+			s += fmt.Sprintf(" tcp:%d=up(%s)", tcp.Port, tcp.Latency)
+		} else {
+			s += fmt.Sprintf(" tcp:%d=down(%v)", tcp.Port, tcp.Err)
+		}
+	}
+	return s
+}
+
+// Prober sends Count echo requests to peer over interfaceName and returns
+// the aggregated Result.
+type Prober interface {
+	Probe(ctx context.Context, interfaceName, peer string, count int) (Result, error)
+}
+
+// New returns the Prober implementation for kind.
+func New(kind Kind, timeout time.Duration) (Prober, error) {
+	switch kind {
+	case KindICMP, "":
+		return &icmpProber{timeout: timeout}, nil
+	case KindExec:
+		return &execProber{timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown prober kind %q", kind)
+	}
+}
+
+// stats computes min/avg/max/stddev over a set of successful RTT samples.
+func stats(samples []time.Duration) (min, avg, max, stddev time.Duration) {
+	if len(samples) == 0 {
+		return 0, 0, 0, 0
+	}
+	min, max = samples[0], samples[0]
+	var sum time.Duration
+	for _, s := range samples {
+		if s < min {
+			min = s
+		}
+		if s > max {
+			max = s
+		}
+		sum += s
+	}
+	avg = sum / time.Duration(len(samples))
+
+	var variance float64
+	for _, s := range samples {
+		d := float64(s - avg)
+		variance += d * d
+	}
+	variance /= float64(len(samples))
+	stddev = time.Duration(math.Sqrt(variance))
+	return min, avg, max, stddev
+}
@@ -0,0 +1,81 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TCPResult is the outcome of one TCP connect probe against a single
+// peer port.
+type TCPResult struct {
+	Port      int
+	Connected bool
+	Latency   time.Duration
+	Err       error
+}
+
+// TCPProbe opens TCP connections against a configurable list of ports,
+// bound to the same interface the ICMP probes use, to catch Portworx
+// connectivity problems (blocked service ports) that ping alone misses.
+type TCPProbe struct {
+	timeout time.Duration
+}
+
+// NewTCPProbe returns a TCPProbe that gives up on a connect attempt
+// after timeout.
+func NewTCPProbe(timeout time.Duration) *TCPProbe {
+	return &TCPProbe{timeout: timeout}
+}
+
+// Probe dials peer on each of ports and returns one TCPResult per port.
+func (t *TCPProbe) Probe(ctx context.Context, interfaceName, peer string, ports []int) ([]TCPResult, error) {
+	dialer := &net.Dialer{Timeout: t.timeout}
+	if interfaceName != "" {
+		localAddr, err := interfaceLocalAddr(interfaceName)
+		if err != nil {
+			return nil, fmt.Errorf("resolve local address for %s: %w", interfaceName, err)
+		}
+		dialer.LocalAddr = localAddr
+	}
+
+	results := make([]TCPResult, 0, len(ports))
+	for _, port := range ports {
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(peer, fmt.Sprintf("%d", port)))
+		res := TCPResult{Port: port}
+		if err != nil {
+			res.Err = err
+		} else {
+			res.Connected = true
+			res.Latency = time.Since(start)
+			conn.Close()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// interfaceLocalAddr returns the first IPv4 address bound to
+// interfaceName, suitable for use as a net.Dialer.LocalAddr.
+func interfaceLocalAddr(interfaceName string) (*net.TCPAddr, error) {
+	iface, err := net.InterfaceByName(interfaceName)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return &net.TCPAddr{IP: ip4}, nil
+		}
+	}
+	return nil, fmt.Errorf("no IPv4 address found on interface %s", interfaceName)
+}
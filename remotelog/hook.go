@@ -0,0 +1,156 @@
+// Package remotelog ships ERROR-and-above logrus entries to a remote
+// collector over a reconnecting TCP or syslog connection, so a slow or
+// unreachable collector can't block the pinger goroutines.
+package remotelog
+
+import (
+	"fmt"
+	"log/syslog"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// bufferSize bounds the in-memory ring buffer of pending log lines. Once
+// full, the oldest buffered line is dropped to make room for the newest.
+const bufferSize = 1000
+
+// Hook ships formatted log lines to target, which is either
+// tcp://host:port or syslog://host:port.
+type Hook struct {
+	target    string
+	formatter logrus.Formatter
+
+	mu     sync.Mutex
+	buffer [][]byte
+
+	notify chan struct{}
+}
+
+// NewHook parses target and starts the background shipper goroutine.
+func NewHook(target string) (*Hook, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("parse remote log target %q: %w", target, err)
+	}
+	switch u.Scheme {
+	case "tcp", "syslog":
+	default:
+		return nil, fmt.Errorf("unsupported remote log scheme %q, want tcp:// or syslog://", u.Scheme)
+	}
+
+	h := &Hook{
+		target:    target,
+		formatter: &logrus.JSONFormatter{},
+		notify:    make(chan struct{}, 1),
+	}
+	go h.run(u)
+	return h, nil
+}
+
+// Levels ships ERROR level and above, matching what's worth waking
+// someone up over.
+func (h *Hook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire enqueues entry for shipping without blocking the caller.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	if len(h.buffer) >= bufferSize {
+		h.buffer = h.buffer[1:]
+	}
+	h.buffer = append(h.buffer, line)
+	h.mu.Unlock()
+
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// writeCloser is the subset of net.Conn that both a TCP connection and a
+// syslog writer satisfy.
+type writeCloser interface {
+	Write(p []byte) (int, error)
+	Close() error
+}
+
+// run dials target, reconnecting with backoff, and drains the buffer
+// over the connection whenever new lines arrive.
+func (h *Hook) run(u *url.URL) {
+	backoff := time.Second
+	const maxBackoff = 30 * time.Second
+
+	for {
+		conn, err := h.dial(u)
+		if err != nil {
+			time.Sleep(backoff)
+			if backoff < maxBackoff {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+
+		if !h.drain(conn) {
+			conn.Close()
+			continue
+		}
+		conn.Close()
+	}
+}
+
+func (h *Hook) dial(u *url.URL) (writeCloser, error) {
+	if u.Scheme == "syslog" {
+		writer, err := syslog.Dial("tcp", u.Host, syslog.LOG_ERR, "network_monitor")
+		if err != nil {
+			return nil, err
+		}
+		return syslogConn{writer}, nil
+	}
+	return net.DialTimeout("tcp", u.Host, 5*time.Second)
+}
+
+// drain writes every buffered line to conn, waiting for new lines to
+// arrive via notify. It returns false if a write fails so run can
+// reconnect.
+func (h *Hook) drain(conn writeCloser) bool {
+	for {
+		h.mu.Lock()
+		var line []byte
+		if len(h.buffer) > 0 {
+			line, h.buffer = h.buffer[0], h.buffer[1:]
+		}
+		h.mu.Unlock()
+
+		if line == nil {
+			<-h.notify
+			continue
+		}
+
+		if _, err := conn.Write(append(line, '\n')); err != nil {
+			h.mu.Lock()
+			h.buffer = append([][]byte{line}, h.buffer...)
+			h.mu.Unlock()
+			return false
+		}
+	}
+}
+
+// syslogConn adapts a *syslog.Writer to the net.Conn subset Hook needs.
+type syslogConn struct {
+	w *syslog.Writer
+}
+
+func (s syslogConn) Write(p []byte) (int, error) { return s.w.Write(p) }
+func (s syslogConn) Close() error                { return s.w.Close() }